@@ -0,0 +1,160 @@
+package getter
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hirochachacha/go-smb2"
+)
+
+// defaultSmbIdleTimeout is how long an idle pooled SMB session is kept
+// before it is closed, mirroring rclone's SMB backend default.
+const defaultSmbIdleTimeout = 60 * time.Second
+
+// smbConnKey identifies a distinct SMB endpoint to pool connections for.
+type smbConnKey struct {
+	host   string
+	share  string
+	user   string
+	domain string
+}
+
+// smbConn is a single pooled, authenticated SMB session mounted on a share.
+type smbConn struct {
+	netConn net.Conn
+	session *smb2.Session
+	share   *smb2.Share
+}
+
+func (c *smbConn) close() {
+	smbConnCloser(c)
+}
+
+// smbConnCloser performs the real teardown of a smbConn and is swapped out
+// in tests so pool bookkeeping (acquire/release/expire) can be exercised
+// without a live SMB session to mount and log off.
+var smbConnCloser = func(c *smbConn) {
+	c.share.Umount()
+	c.session.Logoff()
+	c.netConn.Close()
+}
+
+// smbConnPool pools authenticated SMB sessions so that repeated Get/GetFile/
+// Mode calls against the same endpoint (host+share+user+domain) reuse a
+// session instead of dialing and authenticating from scratch every time.
+// Modeled on rclone's SMB connpool.go.
+type smbConnPool struct {
+	mu          sync.Mutex
+	idle        map[smbConnKey][]*smbConn
+	sem         map[smbConnKey]chan struct{}
+	maxPerHost  int
+	idleTimeout time.Duration
+}
+
+func newSmbConnPool(maxPerHost int, idleTimeout time.Duration) *smbConnPool {
+	if maxPerHost <= 0 {
+		maxPerHost = 1
+	}
+	if idleTimeout <= 0 {
+		idleTimeout = defaultSmbIdleTimeout
+	}
+	return &smbConnPool{
+		idle:        make(map[smbConnKey][]*smbConn),
+		sem:         make(map[smbConnKey]chan struct{}),
+		maxPerHost:  maxPerHost,
+		idleTimeout: idleTimeout,
+	}
+}
+
+// semaphore returns, creating it if necessary, the semaphore bounding the
+// number of concurrent sessions held against key.
+func (p *smbConnPool) semaphore(key smbConnKey) chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sem, ok := p.sem[key]
+	if !ok {
+		sem = make(chan struct{}, p.maxPerHost)
+		p.sem[key] = sem
+	}
+	return sem
+}
+
+// acquire blocks until a slot in key's semaphore is available (or ctx is
+// done) and returns an idle pooled connection for key, or nil if none is
+// idle and the caller should dial a new one. On success the caller holds
+// the slot and must call the returned release func exactly once, passing
+// whichever connection it ends up using (c itself, or a freshly dialed one)
+// along with the error from the operation it performed: a nil error returns
+// the connection to the pool for reuse, a non-nil error closes it, and
+// either way the slot is released. If the caller never obtains a usable
+// connection (e.g. dialing a replacement fails), it must still call release
+// with a nil connection to free the slot.
+func (p *smbConnPool) acquire(ctx context.Context, key smbConnKey) (c *smbConn, release func(*smbConn, error), err error) {
+	sem := p.semaphore(key)
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+
+	release = func(c *smbConn, err error) {
+		defer func() { <-sem }()
+		if c == nil {
+			return
+		}
+		if err != nil {
+			c.close()
+			return
+		}
+		p.mu.Lock()
+		p.idle[key] = append(p.idle[key], c)
+		p.mu.Unlock()
+		// Each pooled connection gets its own idle timer, so one key's
+		// traffic can never keep another key's idle connection alive (or
+		// cut it short): expire only closes c, and only if c is still the
+		// very one sitting idle when idleTimeout elapses.
+		time.AfterFunc(p.idleTimeout, func() { p.expire(key, c) })
+	}
+
+	p.mu.Lock()
+	conns := p.idle[key]
+	if len(conns) > 0 {
+		c = conns[len(conns)-1]
+		p.idle[key] = conns[:len(conns)-1]
+	}
+	p.mu.Unlock()
+
+	return c, release, nil
+}
+
+// expire closes c and drops it from key's idle list, but only if c is still
+// there: if it was acquired since this timer was scheduled and is still in
+// use, this is a no-op. If it has since been released back to the pool,
+// this earlier timer still closes it, so a reused connection can be closed
+// after less than idleTimeout of idleness.
+func (p *smbConnPool) expire(key smbConnKey, c *smbConn) {
+	p.mu.Lock()
+	conns := p.idle[key]
+	idx := -1
+	for i, ic := range conns {
+		if ic == c {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		p.mu.Unlock()
+		return
+	}
+	conns = append(conns[:idx], conns[idx+1:]...)
+	if len(conns) == 0 {
+		delete(p.idle, key)
+	} else {
+		p.idle[key] = conns
+	}
+	p.mu.Unlock()
+
+	c.close()
+}
@@ -0,0 +1,180 @@
+package getter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// withFakeSmbConnCloser swaps smbConnCloser for one that just records which
+// conns were closed, avoiding the need for a live SMB session in these
+// pool-bookkeeping tests, and restores the real closer on cleanup.
+func withFakeSmbConnCloser(t *testing.T) *sync.Map {
+	t.Helper()
+	var closed sync.Map
+	orig := smbConnCloser
+	smbConnCloser = func(c *smbConn) { closed.Store(c, true) }
+	t.Cleanup(func() { smbConnCloser = orig })
+	return &closed
+}
+
+func wasClosed(closed *sync.Map, c *smbConn) bool {
+	_, ok := closed.Load(c)
+	return ok
+}
+
+func TestSmbConnPoolAcquireNoIdleConn(t *testing.T) {
+	withFakeSmbConnCloser(t)
+	p := newSmbConnPool(1, time.Minute)
+	key := smbConnKey{host: "h", share: "s"}
+
+	c, release, err := p.acquire(context.Background(), key)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if c != nil {
+		t.Fatalf("expected no idle conn on first acquire, got %v", c)
+	}
+	release(nil, nil)
+}
+
+func TestSmbConnPoolReleaseReusesHealthyConn(t *testing.T) {
+	withFakeSmbConnCloser(t)
+	p := newSmbConnPool(1, time.Minute)
+	key := smbConnKey{host: "h", share: "s"}
+
+	_, release, err := p.acquire(context.Background(), key)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	dialed := &smbConn{}
+	release(dialed, nil)
+
+	c, release2, err := p.acquire(context.Background(), key)
+	if err != nil {
+		t.Fatalf("second acquire: %v", err)
+	}
+	if c != dialed {
+		t.Fatalf("expected reuse of pooled conn %v, got %v", dialed, c)
+	}
+	release2(c, nil)
+}
+
+func TestSmbConnPoolReleaseWithErrorClosesInsteadOfPooling(t *testing.T) {
+	closed := withFakeSmbConnCloser(t)
+	p := newSmbConnPool(1, time.Minute)
+	key := smbConnKey{host: "h", share: "s"}
+
+	_, release, err := p.acquire(context.Background(), key)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	dialed := &smbConn{}
+	release(dialed, errBroken)
+
+	if !wasClosed(closed, dialed) {
+		t.Fatalf("expected conn to be closed after a failed operation")
+	}
+
+	c, release2, err := p.acquire(context.Background(), key)
+	if err != nil {
+		t.Fatalf("second acquire: %v", err)
+	}
+	if c != nil {
+		t.Fatalf("expected no pooled conn after release with error, got %v", c)
+	}
+	release2(nil, nil)
+}
+
+func TestSmbConnPoolAcquireBlocksUntilReleased(t *testing.T) {
+	withFakeSmbConnCloser(t)
+	p := newSmbConnPool(1, time.Minute)
+	key := smbConnKey{host: "h", share: "s"}
+
+	_, release, err := p.acquire(context.Background(), key)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := p.acquire(ctx, key); err == nil {
+		t.Fatalf("expected second acquire to block while the slot is held")
+	}
+
+	release(nil, nil)
+
+	c, release2, err := p.acquire(context.Background(), key)
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release2(c, nil)
+}
+
+func TestSmbConnPoolExpireClosesOnlyTheIdleConnItWasScheduledFor(t *testing.T) {
+	closed := withFakeSmbConnCloser(t)
+	p := newSmbConnPool(2, time.Minute)
+	keyA := smbConnKey{host: "a", share: "s"}
+	keyB := smbConnKey{host: "b", share: "s"}
+
+	_, releaseA, _ := p.acquire(context.Background(), keyA)
+	connA := &smbConn{}
+	releaseA(connA, nil)
+
+	_, releaseB, _ := p.acquire(context.Background(), keyB)
+	connB := &smbConn{}
+	releaseB(connB, nil)
+
+	// Simulate keyA's idle timer firing; keyB's idle conn must be untouched,
+	// which would not hold with a single pool-wide timer.
+	p.expire(keyA, connA)
+
+	if !wasClosed(closed, connA) {
+		t.Fatalf("expected connA to be closed by its own expiry")
+	}
+	if wasClosed(closed, connB) {
+		t.Fatalf("connB must not be closed by keyA's expiry")
+	}
+
+	if _, ok := p.idle[keyA]; ok {
+		t.Fatalf("expired conn should be removed from the idle list")
+	}
+	if conns := p.idle[keyB]; len(conns) != 1 || conns[0] != connB {
+		t.Fatalf("keyB's idle conn should be unaffected, got %v", conns)
+	}
+}
+
+func TestSmbConnPoolExpireIsNoopIfConnAlreadyReacquired(t *testing.T) {
+	closed := withFakeSmbConnCloser(t)
+	p := newSmbConnPool(1, time.Minute)
+	key := smbConnKey{host: "h", share: "s"}
+
+	_, release, _ := p.acquire(context.Background(), key)
+	conn := &smbConn{}
+	release(conn, nil)
+
+	// Reacquire the pooled conn before its (simulated) idle timer fires.
+	reacquired, release2, err := p.acquire(context.Background(), key)
+	if err != nil {
+		t.Fatalf("reacquire: %v", err)
+	}
+	if reacquired != conn {
+		t.Fatalf("expected to reacquire the pooled conn")
+	}
+
+	p.expire(key, conn)
+	if wasClosed(closed, conn) {
+		t.Fatalf("expire must not close a conn that is back in use")
+	}
+
+	release2(conn, nil)
+}
+
+// errBroken is a stand-in for whatever operation error a caller passes to
+// release to signal the session is no longer healthy.
+var errBroken = &testOpError{"broken session"}
+
+type testOpError struct{ msg string }
+
+func (e *testOpError) Error() string { return e.msg }